@@ -0,0 +1,29 @@
+package aws
+
+import "gopkg.in/alecthomas/kingpin.v2"
+
+// S3Config holds the configuration needed to scan chunks out of an S3 bucket.
+type S3Config struct {
+	BucketName string
+	Region     string
+	Endpoint   string
+}
+
+// Register adds the S3 flags to the given command.
+func (cfg *S3Config) Register(cmd *kingpin.CmdClause) {
+	cmd.Flag("chunk.s3.bucketname", "specify s3 bucket to scan for chunks").StringVar(&cfg.BucketName)
+	cmd.Flag("chunk.s3.region", "aws region the s3 bucket lives in").StringVar(&cfg.Region)
+	cmd.Flag("chunk.s3.endpoint", "custom s3 endpoint, useful for s3-compatible stores").StringVar(&cfg.Endpoint)
+}
+
+// DynamoDBConfig holds the configuration needed to delete index entries from DynamoDB.
+type DynamoDBConfig struct {
+	Region   string
+	Endpoint string
+}
+
+// Register adds the DynamoDB flags to the given command.
+func (cfg *DynamoDBConfig) Register(cmd *kingpin.CmdClause) {
+	cmd.Flag("dynamodb.region", "aws region the index tables live in").StringVar(&cfg.Region)
+	cmd.Flag("dynamodb.endpoint", "custom dynamodb endpoint, useful for dynamodb-local").StringVar(&cfg.Endpoint)
+}
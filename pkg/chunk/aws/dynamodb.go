@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	cortexChunk "github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/pkg/errors"
+)
+
+const dynamoDBBatchSize = 25
+
+// DynamoDBIndexDeleter deletes index entries from a DynamoDB-backed chunk index.
+type DynamoDBIndexDeleter struct {
+	client *dynamodb.DynamoDB
+}
+
+// NewDynamoDBIndexDeleter creates a new DynamoDBIndexDeleter from the given config.
+func NewDynamoDBIndexDeleter(ctx context.Context, cfg DynamoDBConfig) (*DynamoDBIndexDeleter, error) {
+	awsCfg := aws.NewConfig()
+	if cfg.Region != "" {
+		awsCfg = awsCfg.WithRegion(cfg.Region)
+	}
+	if cfg.Endpoint != "" {
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint)
+	}
+
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create aws session")
+	}
+
+	return &DynamoDBIndexDeleter{client: dynamodb.New(sess)}, nil
+}
+
+// DeleteEntry deletes a single index entry using BatchWriteItem.
+func (d *DynamoDBIndexDeleter) DeleteEntry(ctx context.Context, entry cortexChunk.IndexEntry) error {
+	_, err := d.client.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+		RequestItems: map[string][]*dynamodb.WriteRequest{
+			entry.TableName: {
+				{
+					DeleteRequest: &dynamodb.DeleteRequest{
+						Key: map[string]*dynamodb.AttributeValue{
+							"h": {S: aws.String(entry.HashValue)},
+							"r": {B: entry.RangeValue},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to delete dynamodb entry")
+	}
+
+	return nil
+}
+
+// DeleteSeries looks up every range matching query via Query, then deletes the
+// matching rows in batches of dynamoDBBatchSize using BatchWriteItem.
+func (d *DynamoDBIndexDeleter) DeleteSeries(ctx context.Context, query cortexChunk.IndexQuery) ([]error, error) {
+	var writeRequests []*dynamodb.WriteRequest
+
+	err := d.client.QueryPagesWithContext(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(query.TableName),
+		KeyConditionExpression: aws.String("h = :h"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":h": {S: aws.String(query.HashValue)},
+		},
+	}, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		for _, item := range page.Items {
+			writeRequests = append(writeRequests, &dynamodb.WriteRequest{
+				DeleteRequest: &dynamodb.DeleteRequest{
+					Key: map[string]*dynamodb.AttributeValue{
+						"h": item["h"],
+						"r": item["r"],
+					},
+				},
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to query dynamodb for series")
+	}
+
+	var errs []error
+	for i := 0; i < len(writeRequests); i += dynamoDBBatchSize {
+		end := i + dynamoDBBatchSize
+		if end > len(writeRequests) {
+			end = len(writeRequests)
+		}
+
+		_, err := d.client.BatchWriteItemWithContext(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]*dynamodb.WriteRequest{
+				query.TableName: writeRequests[i:end],
+			},
+		})
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "unable to batch delete dynamodb entries"))
+		}
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	cortexChunk "github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/grafana/cortex-tool/pkg/chunk/filter"
+	"github.com/pkg/errors"
+)
+
+// S3Scanner scans an S3 bucket for chunks, listing objects page by page
+// under the table's key prefix.
+type S3Scanner struct {
+	client *s3.S3
+	bucket string
+}
+
+// NewS3Scanner creates a new S3Scanner from the given config.
+func NewS3Scanner(ctx context.Context, cfg S3Config) (*S3Scanner, error) {
+	awsCfg := aws.NewConfig()
+	if cfg.Region != "" {
+		awsCfg = awsCfg.WithRegion(cfg.Region)
+	}
+	if cfg.Endpoint != "" {
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint)
+	}
+
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create aws session")
+	}
+
+	return &S3Scanner{
+		client: s3.New(sess),
+		bucket: cfg.BucketName,
+	}, nil
+}
+
+// Scan lists every object under tableName, scoping the listing to fltr.User's
+// prefix when one is set, and emits the chunks that pass fltr. The owning
+// tenant is parsed from each object's own key rather than assumed from
+// fltr, so objects belonging to other tenants that happen to share the
+// table are never mistakenly matched.
+func (s *S3Scanner) Scan(ctx context.Context, tableName string, fltr *filter.Filter, out chan cortexChunk.Chunk) error {
+	prefix := tableName + "/"
+	if fltr.User != "" {
+		prefix += fltr.User + "/"
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	var pageErr error
+	err := s.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			key := aws.StringValue(obj.Key)
+
+			userID, chunkKey, err := parseChunkObjectKey(tableName, key)
+			if err != nil {
+				pageErr = err
+				return false
+			}
+
+			chk, err := cortexChunk.ParseExternalKey(userID, chunkKey)
+			if err != nil {
+				pageErr = errors.Wrapf(err, "unable to parse chunk key %v", key)
+				return false
+			}
+
+			if !fltr.Match(chk) {
+				continue
+			}
+
+			out <- chk
+		}
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to list s3 objects")
+	}
+
+	return pageErr
+}
+
+// parseChunkObjectKey splits a "<tableName>/<userID>/<chunkExternalKey>"
+// object key into its userID and chunkExternalKey parts.
+func parseChunkObjectKey(tableName, key string) (string, string, error) {
+	rest := strings.TrimPrefix(key, tableName+"/")
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("unexpected chunk object key %v", key)
+	}
+
+	return parts[0], parts[1], nil
+}
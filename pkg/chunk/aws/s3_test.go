@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChunkObjectKey(t *testing.T) {
+	tt := []struct {
+		name      string
+		tableName string
+		key       string
+
+		expectedUserID   string
+		expectedChunkKey string
+		expectErr        bool
+	}{
+		{
+			name:             "a well formed chunk object key",
+			tableName:        "chunks_1234",
+			key:              "chunks_1234/fake-user/2a1b3c:170:170:abcdef",
+			expectedUserID:   "fake-user",
+			expectedChunkKey: "2a1b3c:170:170:abcdef",
+		},
+		{
+			name:             "a chunk key that itself contains a slash",
+			tableName:        "chunks_1234",
+			key:              "chunks_1234/fake-user/2a1b3c/170:170:abcdef",
+			expectedUserID:   "fake-user",
+			expectedChunkKey: "2a1b3c/170:170:abcdef",
+		},
+		{
+			name:      "a key missing the chunk key segment",
+			tableName: "chunks_1234",
+			key:       "chunks_1234/fake-user",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			userID, chunkKey, err := parseChunkObjectKey(tc.tableName, tc.key)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedUserID, userID)
+			require.Equal(t, tc.expectedChunkKey, chunkKey)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package cassandra
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"strings"
+
+	cortexChunk "github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/gocql/gocql"
+	"github.com/pkg/errors"
+)
+
+// IndexDeleter deletes index entries from a Cassandra-backed chunk index.
+type IndexDeleter struct {
+	session *gocql.Session
+}
+
+// NewIndexDeleter creates a new IndexDeleter from the given config.
+func NewIndexDeleter(ctx context.Context, cfg Config) (*IndexDeleter, error) {
+	cluster := gocql.NewCluster(strings.Split(cfg.Addresses, ",")...)
+	cluster.Keyspace = cfg.Keyspace
+
+	consistency, err := gocql.ParseConsistencyWrapper(cfg.Consistency)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid cassandra consistency level")
+	}
+	cluster.Consistency = consistency
+
+	if cfg.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}
+	}
+
+	if cfg.TLSEnabled {
+		tlsConfig, err := buildTLSConfig(cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid cassandra tls config")
+		}
+		cluster.SslOpts = &gocql.SslOptions{
+			Config:                 tlsConfig,
+			EnableHostVerification: !cfg.TLSInsecureSkipVerify,
+		}
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create cassandra session")
+	}
+
+	return &IndexDeleter{session: session}, nil
+}
+
+// buildTLSConfig builds the *tls.Config used for the cluster's SslOpts from
+// the optional client certificate/key and CA certificate paths in cfg.
+func buildTLSConfig(cfg Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLSInsecureSkipVerify}
+
+	if cfg.TLSCertPath != "" && cfg.TLSKeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertPath, cfg.TLSKeyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.TLSCAPath != "" {
+		ca, err := ioutil.ReadFile(cfg.TLSCAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read ca certificate")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("unable to parse ca certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// DeleteEntry deletes a single index entry.
+func (d *IndexDeleter) DeleteEntry(ctx context.Context, entry cortexChunk.IndexEntry) error {
+	err := d.session.Query(
+		"DELETE FROM "+entry.TableName+" WHERE hash = ? AND range = ?",
+		entry.HashValue, entry.RangeValue,
+	).WithContext(ctx).Exec()
+	if err != nil {
+		return errors.Wrap(err, "unable to delete cassandra entry")
+	}
+
+	return nil
+}
+
+// DeleteSeries looks up every range matching query and deletes the matching rows.
+func (d *IndexDeleter) DeleteSeries(ctx context.Context, query cortexChunk.IndexQuery) ([]error, error) {
+	iter := d.session.Query(
+		"SELECT range FROM "+query.TableName+" WHERE hash = ?",
+		query.HashValue,
+	).WithContext(ctx).Iter()
+
+	var ranges [][]byte
+	var r []byte
+	for iter.Scan(&r) {
+		ranges = append(ranges, r)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, errors.Wrap(err, "unable to query cassandra for series")
+	}
+
+	var errs []error
+	for _, rangeValue := range ranges {
+		err := d.session.Query(
+			"DELETE FROM "+query.TableName+" WHERE hash = ? AND range = ?",
+			query.HashValue, rangeValue,
+		).WithContext(ctx).Exec()
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "unable to delete cassandra entry"))
+		}
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,33 @@
+package cassandra
+
+import "gopkg.in/alecthomas/kingpin.v2"
+
+// Config holds the configuration needed to delete chunk index entries from Cassandra.
+type Config struct {
+	Addresses   string
+	Keyspace    string
+	Consistency string
+
+	Username string
+	Password string
+
+	TLSEnabled            bool
+	TLSCertPath           string
+	TLSKeyPath            string
+	TLSCAPath             string
+	TLSInsecureSkipVerify bool
+}
+
+// Register adds the Cassandra flags to the given command.
+func (cfg *Config) Register(cmd *kingpin.CmdClause) {
+	cmd.Flag("cassandra.addresses", "comma separated list of cassandra addresses").StringVar(&cfg.Addresses)
+	cmd.Flag("cassandra.keyspace", "cassandra keyspace to use").StringVar(&cfg.Keyspace)
+	cmd.Flag("cassandra.consistency", "cassandra consistency level").Default("QUORUM").StringVar(&cfg.Consistency)
+	cmd.Flag("cassandra.username", "username to authenticate with, if the cluster has PasswordAuthenticator enabled").StringVar(&cfg.Username)
+	cmd.Flag("cassandra.password", "password to authenticate with, if the cluster has PasswordAuthenticator enabled").StringVar(&cfg.Password)
+	cmd.Flag("cassandra.tls-enabled", "enable TLS when connecting to cassandra").BoolVar(&cfg.TLSEnabled)
+	cmd.Flag("cassandra.tls-cert-path", "path to a PEM encoded client certificate, for mutual TLS").StringVar(&cfg.TLSCertPath)
+	cmd.Flag("cassandra.tls-key-path", "path to a PEM encoded client key, for mutual TLS").StringVar(&cfg.TLSKeyPath)
+	cmd.Flag("cassandra.tls-ca-path", "path to a PEM encoded CA certificate, to verify the server with").StringVar(&cfg.TLSCAPath)
+	cmd.Flag("cassandra.tls-insecure-skip-verify", "skip verifying the server's certificate chain and host name").BoolVar(&cfg.TLSInsecureSkipVerify)
+}
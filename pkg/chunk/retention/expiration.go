@@ -0,0 +1,65 @@
+package retention
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// tableNameIntervalSuffixLen is the number of trailing characters Cortex's
+// periodic table naming scheme uses to encode days-since-epoch.
+const tableNameIntervalSuffixLen = 5
+
+const secondsPerDay = int64(24 * time.Hour / time.Second)
+
+// ExtractIntervalFromTableName parses the days-since-epoch suffix
+// (tableName[-5:]) that Cortex appends to periodic table names and returns
+// the [start, start+24h) interval it covers. If the suffix can't be parsed
+// as a number, it returns a wide open interval so callers don't
+// accidentally skip the table.
+func ExtractIntervalFromTableName(tableName string) (time.Time, time.Time) {
+	if len(tableName) < tableNameIntervalSuffixLen {
+		return time.Time{}, time.Unix(1<<62, 0)
+	}
+
+	days, err := strconv.ParseInt(tableName[len(tableName)-tableNameIntervalSuffixLen:], 10, 64)
+	if err != nil {
+		return time.Time{}, time.Unix(1<<62, 0)
+	}
+
+	start := time.Unix(days*secondsPerDay, 0).UTC()
+	return start, start.Add(24 * time.Hour)
+}
+
+// ExpirationChecker decides whether a chunk belonging to a tenant is past
+// its retention window, taking per-tenant stream_retention overrides into
+// account before falling back to defaultRetention.
+type ExpirationChecker struct {
+	overrides        *Overrides
+	defaultRetention time.Duration
+}
+
+// NewExpirationChecker builds an ExpirationChecker from a set of overrides
+// and the retention to apply when no per-tenant rule matches.
+func NewExpirationChecker(overrides *Overrides, defaultRetention time.Duration) *ExpirationChecker {
+	return &ExpirationChecker{
+		overrides:        overrides,
+		defaultRetention: defaultRetention,
+	}
+}
+
+// Expired reports whether a chunk for userID, with the given metric and
+// through timestamp, has fallen outside its retention window as of now.
+func (e *ExpirationChecker) Expired(userID string, metric labels.Labels, through, now time.Time) bool {
+	retention := e.defaultRetention
+
+	for _, sr := range e.overrides.Tenants[userID].StreamRetention {
+		if sr.matches(metric) {
+			retention = sr.Period
+			break
+		}
+	}
+
+	return now.Sub(through) > retention
+}
@@ -0,0 +1,117 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractIntervalFromTableName(t *testing.T) {
+	tt := []struct {
+		name          string
+		tableName     string
+		expectedStart time.Time
+	}{
+		{
+			name:          "a well formed periodic table name",
+			tableName:     "index_18900",
+			expectedStart: time.Unix(18900*secondsPerDay, 0).UTC(),
+		},
+		{
+			name:      "a table name shorter than the suffix",
+			tableName: "idx",
+		},
+		{
+			name:      "a table name whose suffix isn't numeric",
+			tableName: "index_abcde",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end := ExtractIntervalFromTableName(tc.tableName)
+
+			if tc.expectedStart.IsZero() {
+				require.True(t, start.IsZero())
+				require.True(t, end.After(time.Now().AddDate(100, 0, 0)))
+				return
+			}
+
+			require.Equal(t, tc.expectedStart, start)
+			require.Equal(t, tc.expectedStart.Add(24*time.Hour), end)
+		})
+	}
+}
+
+func mustStreamRetention(t *testing.T, selector string, period time.Duration) StreamRetention {
+	t.Helper()
+
+	matchers, err := parser.ParseMetricSelector(selector)
+	require.NoError(t, err)
+
+	return StreamRetention{Period: period, Selector: selector, matchers: matchers}
+}
+
+func TestExpirationCheckerExpired(t *testing.T) {
+	now := time.Now()
+
+	overrides := &Overrides{Tenants: map[string]Limits{
+		"tenant-a": {StreamRetention: []StreamRetention{
+			mustStreamRetention(t, `{namespace="short-lived"}`, time.Hour),
+		}},
+	}}
+	checker := NewExpirationChecker(overrides, 24*time.Hour)
+
+	tt := []struct {
+		name    string
+		userID  string
+		metric  labels.Labels
+		through time.Time
+		expired bool
+	}{
+		{
+			name:    "within the tenant default retention",
+			userID:  "tenant-b",
+			metric:  labels.FromStrings("namespace", "anything"),
+			through: now.Add(-23 * time.Hour),
+			expired: false,
+		},
+		{
+			name:    "past the tenant default retention",
+			userID:  "tenant-b",
+			metric:  labels.FromStrings("namespace", "anything"),
+			through: now.Add(-25 * time.Hour),
+			expired: true,
+		},
+		{
+			name:    "matches a per-tenant override and is within it",
+			userID:  "tenant-a",
+			metric:  labels.FromStrings("namespace", "short-lived"),
+			through: now.Add(-30 * time.Minute),
+			expired: false,
+		},
+		{
+			name:    "matches a per-tenant override and is past it",
+			userID:  "tenant-a",
+			metric:  labels.FromStrings("namespace", "short-lived"),
+			through: now.Add(-2 * time.Hour),
+			expired: true,
+		},
+		{
+			name:    "tenant has overrides but this series doesn't match any of them",
+			userID:  "tenant-a",
+			metric:  labels.FromStrings("namespace", "unrelated"),
+			through: now.Add(-23 * time.Hour),
+			expired: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expired, checker.Expired(tc.userID, tc.metric, tc.through, now))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package retention
+
+import (
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v2"
+)
+
+// StreamRetention describes a single per-tenant retention rule: streams
+// matching Selector are kept for Period instead of the tenant default.
+type StreamRetention struct {
+	Period   time.Duration `yaml:"period"`
+	Selector string        `yaml:"selector"`
+
+	matchers []*labels.Matcher
+}
+
+// Limits is the set of retention rules that apply to a tenant.
+type Limits struct {
+	StreamRetention []StreamRetention `yaml:"stream_retention"`
+}
+
+// Overrides is the per-tenant retention configuration loaded from a Cortex
+// style runtime overrides file.
+type Overrides struct {
+	Tenants map[string]Limits
+}
+
+type overridesFile struct {
+	Overrides map[string]Limits `yaml:"overrides"`
+}
+
+// LoadOverridesFile reads and parses an overrides YAML file, compiling each
+// rule's label selector so it can be matched against a chunk's metric later.
+func LoadOverridesFile(path string) (*Overrides, error) {
+	if path == "" {
+		return &Overrides{Tenants: map[string]Limits{}}, nil
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read overrides file")
+	}
+
+	var f overridesFile
+	if err := yaml.UnmarshalStrict(buf, &f); err != nil {
+		return nil, errors.Wrap(err, "unable to parse overrides file")
+	}
+
+	for tenant, limits := range f.Overrides {
+		for i := range limits.StreamRetention {
+			matchers, err := parser.ParseMetricSelector(limits.StreamRetention[i].Selector)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid selector for tenant %v", tenant)
+			}
+			limits.StreamRetention[i].matchers = matchers
+		}
+		f.Overrides[tenant] = limits
+	}
+
+	return &Overrides{Tenants: f.Overrides}, nil
+}
+
+func (s StreamRetention) matches(metric labels.Labels) bool {
+	for _, m := range s.matchers {
+		if !m.Matches(metric.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
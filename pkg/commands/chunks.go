@@ -9,6 +9,8 @@ import (
 	"github.com/cortexproject/cortex/pkg/chunk"
 	"github.com/cortexproject/cortex/pkg/chunk/gcp"
 	chunkTool "github.com/grafana/cortex-tool/pkg/chunk"
+	toolAWS "github.com/grafana/cortex-tool/pkg/chunk/aws"
+	toolCassandra "github.com/grafana/cortex-tool/pkg/chunk/cassandra"
 	"github.com/grafana/cortex-tool/pkg/chunk/filter"
 	toolGCP "github.com/grafana/cortex-tool/pkg/chunk/gcp"
 	"github.com/pkg/errors"
@@ -42,6 +44,8 @@ func (cfg *SchemaConfig) Load() error {
 
 type chunkCommandOptions struct {
 	Bigtable     gcp.Config
+	DynamoDB     toolAWS.DynamoDBConfig
+	Cassandra    toolCassandra.Config
 	DryRun       bool
 	Schema       SchemaConfig
 	FilterConfig filter.Config
@@ -50,6 +54,7 @@ type chunkCommandOptions struct {
 type deleteChunkCommandOptions struct {
 	chunkCommandOptions
 	GCS gcp.GCSConfig
+	S3  toolAWS.S3Config
 }
 
 type deleteSeriesCommandOptions struct {
@@ -64,6 +69,9 @@ func registerDeleteChunkCommandOptions(cmd *kingpin.CmdClause) {
 	deleteChunkCommand.Flag("bigtable.instance", "bigtable instance to use").StringVar(&deleteChunkCommandOptions.Bigtable.Instance)
 	deleteChunkCommand.Flag("chunk.gcs.bucketname", "specify gcs bucket to scan for chunks").StringVar(&deleteChunkCommandOptions.GCS.BucketName)
 	deleteChunkCommand.Flag("schema-file", "path to file containing cortex schema config").Required().StringVar(&deleteChunkCommandOptions.Schema.FileName)
+	deleteChunkCommandOptions.S3.Register(deleteChunkCommand)
+	deleteChunkCommandOptions.DynamoDB.Register(deleteChunkCommand)
+	deleteChunkCommandOptions.Cassandra.Register(deleteChunkCommand)
 	deleteChunkCommandOptions.FilterConfig.Register(deleteChunkCommand)
 }
 
@@ -74,6 +82,8 @@ func registerDeleteSeriesCommandOptions(cmd *kingpin.CmdClause) {
 	deleteSeriesCommand.Flag("bigtable.project", "bigtable project to use").StringVar(&deleteSeriesCommandOptions.Bigtable.Project)
 	deleteSeriesCommand.Flag("bigtable.instance", "bigtable instance to use").StringVar(&deleteSeriesCommandOptions.Bigtable.Instance)
 	deleteSeriesCommand.Flag("schema-file", "path to file containing cortex schema config").Required().StringVar(&deleteSeriesCommandOptions.Schema.FileName)
+	deleteSeriesCommandOptions.DynamoDB.Register(deleteSeriesCommand)
+	deleteSeriesCommandOptions.Cassandra.Register(deleteSeriesCommand)
 	deleteSeriesCommandOptions.FilterConfig.Register(deleteSeriesCommand)
 }
 
@@ -82,6 +92,7 @@ func RegisterChunkCommands(app *kingpin.Application) {
 	chunkCommand := app.Command("chunk", "Chunk related operations")
 	registerDeleteChunkCommandOptions(chunkCommand)
 	registerDeleteSeriesCommandOptions(chunkCommand)
+	registerRetentionCommandOptions(chunkCommand)
 }
 
 func (c *deleteChunkCommandOptions) run(k *kingpin.ParseContext) error {
@@ -122,6 +133,12 @@ func (c *deleteChunkCommandOptions) run(k *kingpin.ParseContext) error {
 		if err != nil {
 			return errors.Wrap(err, "unable to initialize scanner")
 		}
+	case "s3":
+		logrus.Infof("s3 object store, bucket=%v", c.S3.BucketName)
+		scanner, err = toolAWS.NewS3Scanner(ctx, c.S3)
+		if err != nil {
+			return errors.Wrap(err, "unable to initialize scanner")
+		}
 	default:
 		return fmt.Errorf("object store type %v not supported for deletes", schemaConfig.ObjectType)
 	}
@@ -140,6 +157,18 @@ func (c *deleteChunkCommandOptions) run(k *kingpin.ParseContext) error {
 		if err != nil {
 			return errors.Wrap(err, "unable to initialize deleter")
 		}
+	case "aws", "aws-dynamo":
+		logrus.Infof("dynamodb deleter, region=%v", c.DynamoDB.Region)
+		deleter, err = toolAWS.NewDynamoDBIndexDeleter(ctx, c.DynamoDB)
+		if err != nil {
+			return errors.Wrap(err, "unable to initialize deleter")
+		}
+	case "cassandra":
+		logrus.Infof("cassandra deleter, addresses=%v, keyspace=%v", c.Cassandra.Addresses, c.Cassandra.Keyspace)
+		deleter, err = toolCassandra.NewIndexDeleter(ctx, c.Cassandra)
+		if err != nil {
+			return errors.Wrap(err, "unable to initialize deleter")
+		}
 	default:
 		return fmt.Errorf("index store type %v not supported for deletes", schemaConfig.IndexType)
 	}
@@ -228,6 +257,18 @@ func (c *deleteSeriesCommandOptions) run(k *kingpin.ParseContext) error {
 		if err != nil {
 			return errors.Wrap(err, "unable to initialize deleter")
 		}
+	case "aws", "aws-dynamo":
+		logrus.Infof("dynamodb deleter, region=%v", c.DynamoDB.Region)
+		deleter, err = toolAWS.NewDynamoDBIndexDeleter(ctx, c.DynamoDB)
+		if err != nil {
+			return errors.Wrap(err, "unable to initialize deleter")
+		}
+	case "cassandra":
+		logrus.Infof("cassandra deleter, addresses=%v, keyspace=%v", c.Cassandra.Addresses, c.Cassandra.Keyspace)
+		deleter, err = toolCassandra.NewIndexDeleter(ctx, c.Cassandra)
+		if err != nil {
+			return errors.Wrap(err, "unable to initialize deleter")
+		}
 	default:
 		return fmt.Errorf("index store type %v not supported for deletes", schemaConfig.IndexType)
 	}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/cortexproject/cortex/pkg/chunk/gcp"
+	chunkTool "github.com/grafana/cortex-tool/pkg/chunk"
+	toolAWS "github.com/grafana/cortex-tool/pkg/chunk/aws"
+	toolCassandra "github.com/grafana/cortex-tool/pkg/chunk/cassandra"
+	"github.com/grafana/cortex-tool/pkg/chunk/filter"
+	toolGCP "github.com/grafana/cortex-tool/pkg/chunk/gcp"
+	"github.com/grafana/cortex-tool/pkg/chunk/retention"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+type retentionCommandOptions struct {
+	chunkCommandOptions
+	GCS gcp.GCSConfig
+	S3  toolAWS.S3Config
+
+	OverridesFile    string
+	DefaultRetention time.Duration
+	TableConcurrency int
+}
+
+func registerRetentionCommandOptions(cmd *kingpin.CmdClause) {
+	retentionCommandOptions := &retentionCommandOptions{}
+	retentionCommand := cmd.Command("retention", "Enforces per-tenant retention by deleting index entries for chunks that have aged out").Action(retentionCommandOptions.run)
+	retentionCommand.Flag("dryrun", "if enabled, no delete action will be taken").BoolVar(&retentionCommandOptions.DryRun)
+	retentionCommand.Flag("bigtable.project", "bigtable project to use").StringVar(&retentionCommandOptions.Bigtable.Project)
+	retentionCommand.Flag("bigtable.instance", "bigtable instance to use").StringVar(&retentionCommandOptions.Bigtable.Instance)
+	retentionCommand.Flag("chunk.gcs.bucketname", "specify gcs bucket to scan for chunks").StringVar(&retentionCommandOptions.GCS.BucketName)
+	retentionCommand.Flag("schema-file", "path to file containing cortex schema config").Required().StringVar(&retentionCommandOptions.Schema.FileName)
+	retentionCommand.Flag("retention.overrides-file", "path to a YAML file with per-tenant stream_retention overrides").StringVar(&retentionCommandOptions.OverridesFile)
+	retentionCommand.Flag("retention.default-period", "retention period applied to tenants without an override").Default("744h").DurationVar(&retentionCommandOptions.DefaultRetention)
+	retentionCommand.Flag("retention.table-concurrency", "number of index tables to process concurrently").Default("1").IntVar(&retentionCommandOptions.TableConcurrency)
+	retentionCommandOptions.S3.Register(retentionCommand)
+	retentionCommandOptions.DynamoDB.Register(retentionCommand)
+	retentionCommandOptions.Cassandra.Register(retentionCommand)
+}
+
+func (c *retentionCommandOptions) scannerFor(ctx context.Context, objectType string) (chunkTool.Scanner, error) {
+	switch objectType {
+	case "bigtable":
+		return toolGCP.NewBigtableScanner(ctx, c.Bigtable.Project, c.Bigtable.Instance)
+	case "gcs":
+		return toolGCP.NewGcsScanner(ctx, c.GCS)
+	case "s3":
+		return toolAWS.NewS3Scanner(ctx, c.S3)
+	default:
+		return nil, errors.Errorf("object store type %v not supported for retention", objectType)
+	}
+}
+
+func (c *retentionCommandOptions) deleterFor(ctx context.Context, indexType string) (chunkTool.Deleter, error) {
+	switch indexType {
+	case "bigtable":
+		return toolGCP.NewStorageIndexDeleter(ctx, c.Bigtable)
+	case "bigtable-hashed":
+		c.Bigtable.DistributeKeys = true
+		return toolGCP.NewStorageIndexDeleter(ctx, c.Bigtable)
+	case "aws", "aws-dynamo":
+		return toolAWS.NewDynamoDBIndexDeleter(ctx, c.DynamoDB)
+	case "cassandra":
+		return toolCassandra.NewIndexDeleter(ctx, c.Cassandra)
+	default:
+		return nil, errors.Errorf("index store type %v not supported for retention", indexType)
+	}
+}
+
+// processTable scans a single chunk table, deleting the index entries of
+// every chunk the ExpirationChecker considers expired.
+func (c *retentionCommandOptions) processTable(ctx context.Context, schemaConfig *chunk.PeriodConfig, tableName string, checker *retention.ExpirationChecker) error {
+	start, end := retention.ExtractIntervalFromTableName(tableName)
+
+	scanner, err := c.scannerFor(ctx, schemaConfig.ObjectType)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize scanner")
+	}
+
+	deleter, err := c.deleterFor(ctx, schemaConfig.IndexType)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize deleter")
+	}
+
+	fltr := filter.NewMetricFilter(filter.Config{From: start.Unix(), To: end.Unix()})
+	schema := schemaConfig.CreateSchema()
+	now := time.Now()
+
+	outChan := make(chan chunk.Chunk, 100)
+	errChan := make(chan error, 1)
+
+	go func() {
+		for chk := range outChan {
+			if !checker.Expired(chk.UserID, chk.Metric, chk.Through.Time(), now) {
+				continue
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"chunkID": chk.ExternalKey(),
+				"table":   tableName,
+				"dryrun":  c.DryRun,
+			}).Infoln("found chunk past its retention period")
+
+			if c.DryRun {
+				continue
+			}
+
+			entries, err := schema.GetChunkWriteEntries(chk.From, chk.Through, chk.UserID, chk.Metric.Get(labels.MetricName), chk.Metric, chk.ExternalKey())
+			if err != nil {
+				logrus.WithError(err).Errorln("unable to compute index entries for chunk")
+				continue
+			}
+
+			for _, e := range entries {
+				if err := deleter.DeleteEntry(ctx, e); err != nil {
+					logrus.WithError(err).Errorln("unable to delete index entry")
+				}
+			}
+		}
+		close(errChan)
+	}()
+
+	err = scanner.Scan(ctx, tableName, fltr, outChan)
+	close(outChan)
+	<-errChan
+	if err != nil {
+		return errors.Wrap(err, "scan failed")
+	}
+
+	return nil
+}
+
+func (c *retentionCommandOptions) run(k *kingpin.ParseContext) error {
+	err := c.Schema.Load()
+	if err != nil {
+		return errors.Wrap(err, "unable to load schema")
+	}
+
+	overrides, err := retention.LoadOverridesFile(c.OverridesFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to load retention overrides")
+	}
+	checker := retention.NewExpirationChecker(overrides, c.DefaultRetention)
+
+	tableConcurrency := c.TableConcurrency
+	if tableConcurrency <= 0 {
+		tableConcurrency = 1
+	}
+
+	ctx := context.Background()
+	g, ctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, tableConcurrency)
+
+	const daySeconds = 24 * 60 * 60
+	now := time.Now().Unix()
+
+	for i, schemaConfig := range c.Schema.Configs {
+		schemaConfig := schemaConfig
+
+		// Each config only owns the time range up to the start of the next
+		// one; otherwise a later config's days get swept twice, once here
+		// under the wrong ObjectType/IndexType and once under the config
+		// that actually owns them.
+		upper := now
+		if i+1 < len(c.Schema.Configs) {
+			upper = c.Schema.Configs[i+1].From.Unix()
+		}
+
+		seen := map[string]bool{}
+		for ts := schemaConfig.From.Unix(); ts < upper; ts += daySeconds {
+			tableName := schemaConfig.ChunkTables.TableFor(ts)
+			if seen[tableName] {
+				continue
+			}
+			seen[tableName] = true
+
+			sem <- struct{}{}
+			g.Go(func() error {
+				defer func() { <-sem }()
+				return c.processTable(ctx, schemaConfig, tableName, checker)
+			})
+		}
+	}
+
+	return g.Wait()
+}
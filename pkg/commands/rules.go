@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/grafana/cortex-tool/pkg/rules"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"gopkg.in/yaml.v2"
+)
+
+type rulesVerifyCommandOptions struct {
+	RuleFiles []string
+
+	PrometheusURL    string
+	Tolerance        float64
+	SampleWindow     time.Duration
+	AggregationLabel string
+	Lint             bool
+}
+
+func registerRulesVerifyCommandOptions(cmd *kingpin.CmdClause) {
+	rulesVerifyCommandOptions := &rulesVerifyCommandOptions{}
+	rulesVerifyCommand := cmd.Command("verify", "Shadow-evaluates the rewrite AggregateBy/LintPromQLExpressions would make against a live Prometheus before pushing it").Action(rulesVerifyCommandOptions.run)
+	rulesVerifyCommand.Flag("prometheus-url", "address of the Prometheus/Cortex query_range endpoint to evaluate expressions against").Required().StringVar(&rulesVerifyCommandOptions.PrometheusURL)
+	rulesVerifyCommand.Flag("tolerance", "maximum allowed float difference between the original and rewritten expression's samples").Default("0.001").Float64Var(&rulesVerifyCommandOptions.Tolerance)
+	rulesVerifyCommand.Flag("sample-window", "how far back to evaluate each expression over").Default("1h").DurationVar(&rulesVerifyCommandOptions.SampleWindow)
+	rulesVerifyCommand.Flag("aggregation-label", "if set, verify the rewrite AggregateBy would make with this label").StringVar(&rulesVerifyCommandOptions.AggregationLabel)
+	rulesVerifyCommand.Flag("lint", "also verify the rewrite LintPromQLExpressions would make").BoolVar(&rulesVerifyCommandOptions.Lint)
+	rulesVerifyCommand.Arg("rule-files", "rule namespace files to verify").Required().ExistingFilesVar(&rulesVerifyCommandOptions.RuleFiles)
+}
+
+// RegisterRulesCommands registers the RulesCommand flags with the kingpin application.
+func RegisterRulesCommands(app *kingpin.Application) {
+	rulesCommand := app.Command("rules", "Rule file related operations")
+	registerRulesVerifyCommandOptions(rulesCommand)
+}
+
+func loadRuleNamespace(file string) (rules.RuleNamespace, error) {
+	var ns rules.RuleNamespace
+
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return ns, errors.Wrap(err, "unable to read rule file")
+	}
+
+	if err := yaml.UnmarshalStrict(buf, &ns); err != nil {
+		return ns, errors.Wrap(err, "unable to parse rule file")
+	}
+
+	return ns, nil
+}
+
+// cloneRuleNamespace round-trips ns through YAML to produce a deep copy, so
+// rewriting the clone's expressions doesn't mutate the original.
+func cloneRuleNamespace(ns rules.RuleNamespace) (rules.RuleNamespace, error) {
+	buf, err := yaml.Marshal(ns)
+	if err != nil {
+		return rules.RuleNamespace{}, errors.Wrap(err, "unable to clone rule namespace")
+	}
+
+	var clone rules.RuleNamespace
+	if err := yaml.Unmarshal(buf, &clone); err != nil {
+		return rules.RuleNamespace{}, errors.Wrap(err, "unable to clone rule namespace")
+	}
+
+	return clone, nil
+}
+
+func (c *rulesVerifyCommandOptions) run(k *kingpin.ParseContext) error {
+	if c.AggregationLabel == "" && !c.Lint {
+		return fmt.Errorf("at least one of --aggregation-label or --lint must be set, otherwise there is no rewrite to verify")
+	}
+
+	verifier, err := rules.NewVerifier(rules.VerifyConfig{
+		PrometheusURL: c.PrometheusURL,
+		Tolerance:     c.Tolerance,
+		SampleWindow:  c.SampleWindow,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create verifier")
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+
+	var diffs []rules.RuleDiff
+
+	for _, file := range c.RuleFiles {
+		original, err := loadRuleNamespace(file)
+		if err != nil {
+			return err
+		}
+
+		modified, err := cloneRuleNamespace(original)
+		if err != nil {
+			return err
+		}
+
+		if c.AggregationLabel != "" {
+			if _, _, err := modified.AggregateBy(c.AggregationLabel); err != nil {
+				return errors.Wrapf(err, "unable to rewrite %v", file)
+			}
+		}
+		if c.Lint {
+			if _, _, err := modified.LintPromQLExpressions(); err != nil {
+				return errors.Wrapf(err, "unable to lint %v", file)
+			}
+		}
+
+		for gi, g := range original.Groups {
+			for ri, rule := range g.Rules {
+				modifiedExpr := modified.Groups[gi].Rules[ri].Expr
+				if modifiedExpr == rule.Expr {
+					continue
+				}
+
+				sampleDiffs, err := verifier.VerifyRule(ctx, rule.Expr, modifiedExpr, now)
+				if err != nil {
+					return errors.Wrapf(err, "unable to verify rule %v/%v", g.Name, rule.Alert+rule.Record)
+				}
+
+				if len(sampleDiffs) == 0 {
+					continue
+				}
+
+				diffs = append(diffs, rules.RuleDiff{
+					Namespace:    file,
+					Group:        g.Name,
+					Rule:         rule.Alert + rule.Record,
+					OriginalExpr: rule.Expr,
+					ModifiedExpr: modifiedExpr,
+					Diffs:        sampleDiffs,
+				})
+			}
+		}
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(diffs); err != nil {
+		return errors.Wrap(err, "unable to encode diff")
+	}
+
+	for _, d := range diffs {
+		logrus.WithFields(logrus.Fields{
+			"namespace": d.Namespace,
+			"group":     d.Group,
+			"rule":      d.Rule,
+			"samples":   len(d.Diffs),
+		}).Warnln("rewrite changes query results")
+	}
+	if len(diffs) == 0 {
+		logrus.Infoln("no semantic differences found")
+	}
+
+	return nil
+}
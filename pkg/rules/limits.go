@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultReloadInterval is how often a FileOverrides re-reads its backing
+// file looking for changes.
+const defaultReloadInterval = 10 * time.Second
+
+// RulesLimits is the per-tenant configuration consumed by
+// AggregateByForTenant and LintPromQLExpressionsForTenant so that
+// multi-tenant cortextool rules workflows can enforce Cortex-style
+// overrides instead of a single set of flags for every tenant.
+type RulesLimits interface {
+	// AggregationLabel returns the extra label AggregateByForTenant should
+	// add to every aggregation for userID. An empty string disables
+	// aggregation enforcement for that tenant.
+	AggregationLabel(userID string) string
+
+	// DisallowedLabels returns the label names LintPromQLExpressionsForTenant
+	// should refuse to see on any matcher in a rule belonging to userID.
+	DisallowedLabels(userID string) []string
+}
+
+type tenantLimits struct {
+	AggregationLabel string   `yaml:"aggregation_label"`
+	DisallowedLabels []string `yaml:"disallowed_labels"`
+}
+
+// overridesFile is the on-disk shape of a Cortex-style overrides file.
+type overridesFile struct {
+	Overrides map[string]tenantLimits `yaml:"overrides"`
+}
+
+// FileOverrides is a RulesLimits backed by an overrides YAML file that is
+// periodically reloaded from disk, so operators can change per-tenant rules
+// enforcement without restarting cortextool.
+type FileOverrides struct {
+	path    string
+	done    chan struct{}
+	stopped chan struct{}
+
+	mtx     sync.RWMutex
+	tenants map[string]tenantLimits
+}
+
+// NewFileOverrides loads path and starts watching it for changes every
+// reloadInterval. A reloadInterval <= 0 uses defaultReloadInterval.
+func NewFileOverrides(path string, reloadInterval time.Duration) (*FileOverrides, error) {
+	if reloadInterval <= 0 {
+		reloadInterval = defaultReloadInterval
+	}
+
+	o := &FileOverrides{
+		path:    path,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	if err := o.reload(); err != nil {
+		return nil, err
+	}
+
+	go o.watch(reloadInterval)
+
+	return o, nil
+}
+
+func (o *FileOverrides) reload() error {
+	buf, err := ioutil.ReadFile(o.path)
+	if err != nil {
+		return errors.Wrap(err, "unable to read rules overrides file")
+	}
+
+	var f overridesFile
+	if err := yaml.UnmarshalStrict(buf, &f); err != nil {
+		return errors.Wrap(err, "unable to parse rules overrides file")
+	}
+
+	o.mtx.Lock()
+	o.tenants = f.Overrides
+	o.mtx.Unlock()
+
+	return nil
+}
+
+func (o *FileOverrides) watch(interval time.Duration) {
+	defer close(o.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := o.reload(); err != nil {
+				logrus.WithError(err).Warnln("unable to reload rules overrides file")
+			}
+		case <-o.done:
+			return
+		}
+	}
+}
+
+// Stop stops watching the overrides file for changes. It returns once the
+// watching goroutine has exited, so no reload happens after Stop returns.
+func (o *FileOverrides) Stop() {
+	close(o.done)
+	<-o.stopped
+}
+
+// AggregationLabel implements RulesLimits.
+func (o *FileOverrides) AggregationLabel(userID string) string {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+
+	return o.tenants[userID].AggregationLabel
+}
+
+// DisallowedLabels implements RulesLimits.
+func (o *FileOverrides) DisallowedLabels(userID string) []string {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+
+	return o.tenants[userID].DisallowedLabels
+}
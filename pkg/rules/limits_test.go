@@ -0,0 +1,109 @@
+package rules
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeOverridesFile(t *testing.T, path, contents string) {
+	t.Helper()
+	require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0o600))
+}
+
+func TestNewFileOverrides(t *testing.T) {
+	t.Run("loads an overrides file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "overrides.yaml")
+		writeOverridesFile(t, path, `
+overrides:
+  tenant-a:
+    aggregation_label: cluster
+    disallowed_labels:
+      - namespace
+`)
+
+		o, err := NewFileOverrides(path, time.Hour)
+		require.NoError(t, err)
+		defer o.Stop()
+
+		require.Equal(t, "cluster", o.AggregationLabel("tenant-a"))
+		require.Equal(t, []string{"namespace"}, o.DisallowedLabels("tenant-a"))
+		require.Equal(t, "", o.AggregationLabel("tenant-b"))
+		require.Nil(t, o.DisallowedLabels("tenant-b"))
+	})
+
+	t.Run("errors on a missing file", func(t *testing.T) {
+		_, err := NewFileOverrides(filepath.Join(t.TempDir(), "missing.yaml"), time.Hour)
+		require.Error(t, err)
+	})
+
+	t.Run("errors on malformed yaml", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "overrides.yaml")
+		writeOverridesFile(t, path, `not: [valid`)
+
+		_, err := NewFileOverrides(path, time.Hour)
+		require.Error(t, err)
+	})
+
+	t.Run("a non-positive reload interval falls back to the default", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "overrides.yaml")
+		writeOverridesFile(t, path, `overrides: {}`)
+
+		o, err := NewFileOverrides(path, 0)
+		require.NoError(t, err)
+		defer o.Stop()
+	})
+}
+
+func TestFileOverridesReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	writeOverridesFile(t, path, `
+overrides:
+  tenant-a:
+    aggregation_label: cluster
+`)
+
+	o, err := NewFileOverrides(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer o.Stop()
+
+	require.Equal(t, "cluster", o.AggregationLabel("tenant-a"))
+
+	writeOverridesFile(t, path, `
+overrides:
+  tenant-a:
+    aggregation_label: region
+`)
+
+	require.Eventually(t, func() bool {
+		return o.AggregationLabel("tenant-a") == "region"
+	}, time.Second, 5*time.Millisecond, "reload did not pick up the updated file")
+}
+
+func TestFileOverridesStopStopsWatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.yaml")
+	writeOverridesFile(t, path, `
+overrides:
+  tenant-a:
+    aggregation_label: cluster
+`)
+
+	o, err := NewFileOverrides(path, 10*time.Millisecond)
+	require.NoError(t, err)
+
+	o.Stop()
+
+	// watch's goroutine has exited, so changing the backing file must not
+	// trigger any further reload, and the last-loaded tenants should stick
+	// around unchanged.
+	writeOverridesFile(t, path, `
+overrides:
+  tenant-a:
+    aggregation_label: region
+`)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, "cluster", o.AggregationLabel("tenant-a"))
+}
@@ -0,0 +1,183 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// RuleGroup extends the vendored rulefmt.RuleGroup with the rule-group
+// level fields added by newer Prometheus/Cortex releases (query_offset,
+// evaluation_delay) that the rulefmt package imported here predates.
+type RuleGroup struct {
+	rulefmt.RuleGroup `yaml:",inline"`
+
+	QueryOffset     *model.Duration `yaml:"query_offset,omitempty"`
+	EvaluationDelay *model.Duration `yaml:"evaluation_delay,omitempty"`
+}
+
+// RuleNamespace is a mapping between a namespace and the rule groups it contains.
+type RuleNamespace struct {
+	// Namespace only exists for setting the namespace in the rule group body
+	// instead of deriving it from the file name.
+	Namespace string `yaml:"namespace,omitempty"`
+
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// mapExpressions parses every rule's PromQL expression in the namespace,
+// applies transform to the parsed AST, then re-serializes it back onto the
+// rule. It returns the number of expressions visited, the number that ended
+// up textually different from their original form, and the first error
+// encountered, whether from parsing or from transform itself.
+func (r *RuleNamespace) mapExpressions(transform func(parser.Expr) error) (int, int, error) {
+	count, modified := 0, 0
+
+	for _, g := range r.Groups {
+		for i, rule := range g.Rules {
+			expr, err := parser.ParseExpr(rule.Expr)
+			if err != nil {
+				return count, modified, err
+			}
+			count++
+
+			if err := transform(expr); err != nil {
+				return count, modified, err
+			}
+
+			formatted := expr.String()
+			if formatted != rule.Expr {
+				modified++
+			}
+			g.Rules[i].Expr = formatted
+		}
+	}
+
+	return count, modified, nil
+}
+
+// LintPromQLExpressions parses and re-serializes every rule's PromQL
+// expression, normalizing its formatting to the canonical one produced by
+// the Prometheus PromQL parser.
+func (r *RuleNamespace) LintPromQLExpressions() (int, int, error) {
+	return r.mapExpressions(func(parser.Expr) error { return nil })
+}
+
+// AggregateBy walks every rule's PromQL expression and adds label to the
+// grouping of every aggregation that doesn't use a `without` clause,
+// preserving the aggregation otherwise. This is used to make sure alerting
+// and recording rules keep a cluster (or similarly scoped) label after
+// federation or cross-cluster aggregation.
+func (r *RuleNamespace) AggregateBy(label string) (int, int, error) {
+	return r.mapExpressions(func(expr parser.Expr) error {
+		parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+			agg, ok := node.(*parser.AggregateExpr)
+			if !ok || agg.Without {
+				return nil
+			}
+
+			for _, l := range agg.Grouping {
+				if l == label {
+					return nil
+				}
+			}
+			agg.Grouping = append(agg.Grouping, label)
+
+			return nil
+		})
+		return nil
+	})
+}
+
+// SetQueryOffset sets the rule-group level query_offset on every group in
+// the namespace that doesn't already have one, mirroring Prometheus 2.53's
+// per-group query_offset (and Cortex's long-standing
+// ruler_evaluation_delay_duration) so remote-write delays don't cause
+// missed evaluations. Like AggregateBy and LintPromQLExpressions, it
+// returns the number of groups visited and the number that were changed.
+func (r *RuleNamespace) SetQueryOffset(d time.Duration) (int, int, error) {
+	count, modified := 0, 0
+
+	for i := range r.Groups {
+		count++
+
+		if r.Groups[i].QueryOffset != nil {
+			continue
+		}
+
+		offset := model.Duration(d)
+		r.Groups[i].QueryOffset = &offset
+		modified++
+	}
+
+	return count, modified, nil
+}
+
+// SetEvaluationDelay sets the rule-group level evaluation_delay on every
+// group in the namespace that doesn't already have one. It mirrors
+// Cortex's long-standing ruler_evaluation_delay_duration at the rule-group
+// level, for the same reason SetQueryOffset exists: remote-write delays
+// shouldn't cause missed evaluations.
+func (r *RuleNamespace) SetEvaluationDelay(d time.Duration) (int, int, error) {
+	count, modified := 0, 0
+
+	for i := range r.Groups {
+		count++
+
+		if r.Groups[i].EvaluationDelay != nil {
+			continue
+		}
+
+		delay := model.Duration(d)
+		r.Groups[i].EvaluationDelay = &delay
+		modified++
+	}
+
+	return count, modified, nil
+}
+
+// AggregateByForTenant runs AggregateBy using the aggregation label
+// configured for userID in limits. It is a no-op if limits doesn't define
+// an aggregation label for userID.
+func (r *RuleNamespace) AggregateByForTenant(userID string, limits RulesLimits) (int, int, error) {
+	label := limits.AggregationLabel(userID)
+	if label == "" {
+		return 0, 0, nil
+	}
+
+	return r.AggregateBy(label)
+}
+
+// LintPromQLExpressionsForTenant runs LintPromQLExpressions and additionally
+// rejects any rule whose expression matches on a label userID is not
+// allowed to select on, as configured in limits.
+func (r *RuleNamespace) LintPromQLExpressionsForTenant(userID string, limits RulesLimits) (int, int, error) {
+	disallowed := make(map[string]bool, len(limits.DisallowedLabels(userID)))
+	for _, l := range limits.DisallowedLabels(userID) {
+		disallowed[l] = true
+	}
+
+	return r.mapExpressions(func(expr parser.Expr) error {
+		var err error
+		parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+			vs, ok := node.(*parser.VectorSelector)
+			if !ok {
+				return nil
+			}
+
+			for _, m := range vs.LabelMatchers {
+				if disallowed[m.Name] {
+					err = fmt.Errorf("tenant %v is not allowed to match on label %q", userID, m.Name)
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		return err
+	})
+}
@@ -3,7 +3,9 @@ package rules
 import (
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/pkg/rulefmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,9 +27,9 @@ func TestAggregateBy(t *testing.T) {
 		{
 			name: "no modifcation",
 			rn: RuleNamespace{
-				Groups: []rulefmt.RuleGroup{rulefmt.RuleGroup{Name: "WithoutAggregation", Rules: []rulefmt.Rule{
+				Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "WithoutAggregation", Rules: []rulefmt.Rule{
 					{Alert: "WithoutAggregation", Expr: "up != 1"},
-				}}},
+				}}}},
 			},
 			expectedExpr: []string{"up != 1"},
 			count:        1, modified: 0, expect: nil,
@@ -35,7 +37,7 @@ func TestAggregateBy(t *testing.T) {
 		{
 			name: "no change in the query but lints with 'without' in the aggregation",
 			rn: RuleNamespace{
-				Groups: []rulefmt.RuleGroup{rulefmt.RuleGroup{Name: "SkipWithout", Rules: []rulefmt.Rule{
+				Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "SkipWithout", Rules: []rulefmt.Rule{
 					{Alert: "SkipWithout", Expr: `
 						min without(alertmanager) (
 							rate(prometheus_notifications_errors_total{job="default/prometheus"}[5m])
@@ -45,7 +47,7 @@ func TestAggregateBy(t *testing.T) {
 						* 100
 						> 3
 					`},
-				}}},
+				}}}},
 			},
 			expectedExpr: []string{`min without(alertmanager) (rate(prometheus_notifications_errors_total{job="default/prometheus"}[5m]) / rate(prometheus_notifications_sent_total{job="default/prometheus"}[5m])) * 100 > 3`},
 			count:        1, modified: 1, expect: nil,
@@ -53,14 +55,14 @@ func TestAggregateBy(t *testing.T) {
 		{
 			name: "with an aggregation modification",
 			rn: RuleNamespace{
-				Groups: []rulefmt.RuleGroup{rulefmt.RuleGroup{Name: "WithAggregation", Rules: []rulefmt.Rule{
+				Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "WithAggregation", Rules: []rulefmt.Rule{
 					{Alert: "WithAggregation", Expr: `
 						sum(rate(cortex_prometheus_rule_evaluation_failures_total[1m])) by (namespace, job)
 						/
 						sum(rate(cortex_prometheus_rule_evaluations_total[1m])) by (namespace, job)
 						> 0.01
 					`},
-				}}},
+				}}}},
 			},
 			expectedExpr: []string{"sum by(namespace, job, cluster) (rate(cortex_prometheus_rule_evaluation_failures_total[1m])) / sum by(namespace, job, cluster) (rate(cortex_prometheus_rule_evaluations_total[1m])) > 0.01"},
 			count:        1, modified: 1, expect: nil,
@@ -68,11 +70,11 @@ func TestAggregateBy(t *testing.T) {
 		{
 			name: "with 'count' as the aggregation",
 			rn: RuleNamespace{
-				Groups: []rulefmt.RuleGroup{rulefmt.RuleGroup{Name: "CountAggregation", Rules: []rulefmt.Rule{
+				Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "CountAggregation", Rules: []rulefmt.Rule{
 					{Alert: "CountAggregation", Expr: `
-						count(count by (gitVersion) (label_replace(kubernetes_build_info{job!~"kube-dns|coredns"},"gitVersion","$1","gitVersion","(v[0-9]*.[0-9]*.[0-9]*).*"))) > 1	
+						count(count by (gitVersion) (label_replace(kubernetes_build_info{job!~"kube-dns|coredns"},"gitVersion","$1","gitVersion","(v[0-9]*.[0-9]*.[0-9]*).*"))) > 1
 					`},
-				}}},
+				}}}},
 			},
 			expectedExpr: []string{`count by(cluster) (count by(gitVersion, cluster) (label_replace(kubernetes_build_info{job!~"kube-dns|coredns"}, "gitVersion", "$1", "gitVersion", "(v[0-9]*.[0-9]*.[0-9]*).*"))) > 1`},
 			count:        1, modified: 1, expect: nil,
@@ -97,6 +99,107 @@ func TestAggregateBy(t *testing.T) {
 	}
 }
 
+func TestSetQueryOffset(t *testing.T) {
+	existing := model.Duration(5 * time.Minute)
+
+	tt := []struct {
+		name            string
+		rn              RuleNamespace
+		count, modified int
+		expectedOffsets []*model.Duration
+	}{
+		{
+			name:  "with no groups",
+			rn:    RuleNamespace{},
+			count: 0, modified: 0,
+		},
+		{
+			name: "injection when missing",
+			rn: RuleNamespace{
+				Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "NoOffset"}}},
+			},
+			count: 1, modified: 1,
+			expectedOffsets: []*model.Duration{durationPtr(model.Duration(time.Minute))},
+		},
+		{
+			name: "no-op when already set",
+			rn: RuleNamespace{
+				Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "WithOffset"}, QueryOffset: &existing}},
+			},
+			count: 1, modified: 0,
+			expectedOffsets: []*model.Duration{&existing},
+		},
+		{
+			name: "per-namespace overrides, only missing ones are set",
+			rn: RuleNamespace{
+				Groups: []RuleGroup{
+					{RuleGroup: rulefmt.RuleGroup{Name: "WithOffset"}, QueryOffset: &existing},
+					{RuleGroup: rulefmt.RuleGroup{Name: "NoOffset"}},
+				},
+			},
+			count: 2, modified: 1,
+			expectedOffsets: []*model.Duration{&existing, durationPtr(model.Duration(time.Minute))},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, m, err := tc.rn.SetQueryOffset(time.Minute)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.count, c)
+			assert.Equal(t, tc.modified, m)
+
+			for i, g := range tc.rn.Groups {
+				require.NotNil(t, g.QueryOffset)
+				assert.Equal(t, *tc.expectedOffsets[i], *g.QueryOffset)
+			}
+		})
+	}
+}
+
+func durationPtr(d model.Duration) *model.Duration {
+	return &d
+}
+
+type fakeLimits struct {
+	aggregationLabel string
+	disallowedLabels []string
+}
+
+func (f fakeLimits) AggregationLabel(userID string) string   { return f.aggregationLabel }
+func (f fakeLimits) DisallowedLabels(userID string) []string { return f.disallowedLabels }
+
+func TestAggregateByForTenant(t *testing.T) {
+	rn := RuleNamespace{
+		Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "WithAggregation", Rules: []rulefmt.Rule{
+			{Alert: "WithAggregation", Expr: "sum(rate(cortex_prometheus_rule_evaluation_failures_total[1m])) by (namespace, job)"},
+		}}}},
+	}
+
+	c, m, err := rn.AggregateByForTenant("tenant-a", fakeLimits{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, c)
+	assert.Equal(t, 0, m)
+
+	c, m, err = rn.AggregateByForTenant("tenant-a", fakeLimits{aggregationLabel: "cluster"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, c)
+	assert.Equal(t, 1, m)
+	assert.Equal(t, "sum by(namespace, job, cluster) (rate(cortex_prometheus_rule_evaluation_failures_total[1m]))", rn.Groups[0].Rules[0].Expr)
+}
+
+func TestLintPromQLExpressionsForTenant(t *testing.T) {
+	rn := RuleNamespace{
+		Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Name: "Forbidden", Rules: []rulefmt.Rule{
+			{Alert: "Forbidden", Expr: `up{namespace="other-tenant"} != 1`},
+		}}}},
+	}
+
+	_, _, err := rn.LintPromQLExpressionsForTenant("tenant-a", fakeLimits{disallowedLabels: []string{"namespace"}})
+	require.EqualError(t, err, `tenant tenant-a is not allowed to match on label "namespace"`)
+}
+
 func TestLintPromQLExpressions(t *testing.T) {
 	tt := []struct {
 		name            string
@@ -137,9 +240,9 @@ func TestLintPromQLExpressions(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			r := RuleNamespace{Groups: []rulefmt.RuleGroup{{Rules: []rulefmt.Rule{
+			r := RuleNamespace{Groups: []RuleGroup{{RuleGroup: rulefmt.RuleGroup{Rules: []rulefmt.Rule{
 				{Alert: "AName", Expr: tc.expr},
-			}}}}
+			}}}}}
 
 			c, m, err := r.LintPromQLExpressions()
 			rexpr := r.Groups[0].Rules[0].Expr
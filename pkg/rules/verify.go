@@ -0,0 +1,226 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/sirupsen/logrus"
+)
+
+// VerifyConfig configures a Verifier's connection to the Prometheus/Cortex
+// query_range endpoint used to shadow-evaluate rule expressions, and the
+// window/tolerance used to compare their results.
+type VerifyConfig struct {
+	PrometheusURL string
+	Tolerance     float64
+	SampleWindow  time.Duration
+}
+
+// SampleDiff is a single timestamp at which the original and modified
+// expressions disagree by more than the configured tolerance.
+type SampleDiff struct {
+	Timestamp time.Time `json:"timestamp"`
+	Labels    string    `json:"labels"`
+	Original  float64   `json:"original"`
+	Modified  float64   `json:"modified"`
+	Delta     float64   `json:"delta"`
+}
+
+// RuleDiff is the shadow-evaluation result for a single rule whose
+// expression was rewritten by AggregateBy or LintPromQLExpressions.
+type RuleDiff struct {
+	Namespace    string       `json:"namespace"`
+	Group        string       `json:"group"`
+	Rule         string       `json:"rule"`
+	OriginalExpr string       `json:"original_expr"`
+	ModifiedExpr string       `json:"modified_expr"`
+	Diffs        []SampleDiff `json:"diffs"`
+}
+
+// Verifier shadow-evaluates an original and a rewritten PromQL expression
+// against a live Prometheus/Cortex query_range endpoint, so that a rewrite
+// performed by AggregateBy or LintPromQLExpressions can be checked for
+// unintended semantic changes before it's pushed.
+type Verifier struct {
+	api v1.API
+	cfg VerifyConfig
+}
+
+// NewVerifier creates a Verifier that queries cfg.PrometheusURL.
+func NewVerifier(cfg VerifyConfig) (*Verifier, error) {
+	client, err := api.NewClient(api.Config{Address: cfg.PrometheusURL})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create prometheus client")
+	}
+
+	return &Verifier{
+		api: v1.NewAPI(client),
+		cfg: cfg,
+	}, nil
+}
+
+// VerifyRule runs both originalExpr and modifiedExpr as a query_range over
+// the configured sample window ending at now, and returns every sample
+// pair whose values differ by more than the configured tolerance.
+func (v *Verifier) VerifyRule(ctx context.Context, originalExpr, modifiedExpr string, now time.Time) ([]SampleDiff, error) {
+	r := v1.Range{
+		Start: now.Add(-v.cfg.SampleWindow),
+		End:   now,
+		Step:  v.cfg.SampleWindow / 100,
+	}
+
+	original, err := v.queryRange(ctx, originalExpr, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to evaluate original expression")
+	}
+
+	modified, err := v.queryRange(ctx, modifiedExpr, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to evaluate modified expression")
+	}
+
+	return diffMatrices(original, modified, v.cfg.Tolerance), nil
+}
+
+func (v *Verifier) queryRange(ctx context.Context, expr string, r v1.Range) (model.Matrix, error) {
+	val, warnings, err := v.api.QueryRange(ctx, expr, r)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warnings {
+		logrus.WithField("expr", expr).Warnln(w)
+	}
+
+	matrix, ok := val.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected query_range result type %T", val)
+	}
+
+	return matrix, nil
+}
+
+// diffMatrices compares two matrices series-by-series and sample-by-sample,
+// and reports every pair whose values differ by more than tolerance.
+//
+// Series are matched by exact label-set equality where possible, which
+// covers LintPromQLExpressions (a pure reformat) and is an O(n+m) hash
+// join. Failing that, a series is matched by label subset instead:
+// AggregateBy's entire purpose is to add a grouping label to every
+// aggregation, so a modified series' label set is expected to be a
+// superset of the original's. Since the new grouping label may fan a
+// single original series out into several, every subset match is summed
+// before being compared, rather than diffing the original against each
+// fanned-out series individually.
+func diffMatrices(original, modified model.Matrix, tolerance float64) []SampleDiff {
+	var diffs []SampleDiff
+
+	byLabels := make(map[string]*model.SampleStream, len(modified))
+	for _, ms := range modified {
+		byLabels[ms.Metric.String()] = ms
+	}
+
+	for _, os := range original {
+		if ms, ok := byLabels[os.Metric.String()]; ok {
+			diffs = append(diffs, diffSeries(os, ms, tolerance)...)
+			continue
+		}
+
+		var fannedOut []*model.SampleStream
+		if len(os.Metric) == 0 {
+			fannedOut = modified
+		} else {
+			for _, ms := range modified {
+				if labelsSubsetOf(os.Metric, ms.Metric) {
+					fannedOut = append(fannedOut, ms)
+				}
+			}
+		}
+
+		if ms := sumSeries(fannedOut); ms != nil {
+			// Identify the diff by the original series' own labels, since
+			// the summed series has none of its own and the fanned-out
+			// series it was built from no longer carry useful identity
+			// once collapsed into a single total.
+			ms.Metric = os.Metric
+			diffs = append(diffs, diffSeries(os, ms, tolerance)...)
+		}
+	}
+
+	return diffs
+}
+
+// sumSeries sums a set of series pointwise across their shared timestamps
+// into a single series, so a label-less original series can be verified
+// against the total of however many series its new grouping label fanned
+// the modified expression out into.
+func sumSeries(series []*model.SampleStream) *model.SampleStream {
+	if len(series) == 0 {
+		return nil
+	}
+
+	sums := make(map[model.Time]model.SampleValue)
+	for _, s := range series {
+		for _, v := range s.Values {
+			sums[v.Timestamp] += v.Value
+		}
+	}
+
+	values := make([]model.SamplePair, 0, len(sums))
+	for ts, v := range sums {
+		values = append(values, model.SamplePair{Timestamp: ts, Value: v})
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Timestamp < values[j].Timestamp })
+
+	return &model.SampleStream{Values: values}
+}
+
+// diffSeries compares a single pair of series sample-by-sample and reports
+// every pair whose values differ by more than tolerance.
+func diffSeries(original, modified *model.SampleStream, tolerance float64) []SampleDiff {
+	modifiedByTime := make(map[model.Time]model.SampleValue, len(modified.Values))
+	for _, v := range modified.Values {
+		modifiedByTime[v.Timestamp] = v.Value
+	}
+
+	var diffs []SampleDiff
+	for _, ov := range original.Values {
+		mv, ok := modifiedByTime[ov.Timestamp]
+		if !ok {
+			continue
+		}
+
+		delta := math.Abs(float64(ov.Value - mv))
+		if delta <= tolerance {
+			continue
+		}
+
+		diffs = append(diffs, SampleDiff{
+			Timestamp: ov.Timestamp.Time(),
+			Labels:    modified.Metric.String(),
+			Original:  float64(ov.Value),
+			Modified:  float64(mv),
+			Delta:     delta,
+		})
+	}
+
+	return diffs
+}
+
+// labelsSubsetOf reports whether every label in sub is present, with the
+// same value, in super.
+func labelsSubsetOf(sub, super model.Metric) bool {
+	for name, value := range sub {
+		if super[name] != value {
+			return false
+		}
+	}
+
+	return true
+}
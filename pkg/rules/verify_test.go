@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func series(metric model.Metric, values ...model.SampleValue) *model.SampleStream {
+	pairs := make([]model.SamplePair, len(values))
+	for i, v := range values {
+		pairs[i] = model.SamplePair{Timestamp: model.Time(i), Value: v}
+	}
+	return &model.SampleStream{Metric: metric, Values: pairs}
+}
+
+func TestDiffMatrices(t *testing.T) {
+	tt := []struct {
+		name              string
+		original, modified model.Matrix
+		tolerance         float64
+		expectedDiffs     int
+	}{
+		{
+			name:      "identical series produce no diff",
+			original:  model.Matrix{series(model.Metric{"job": "a"}, 1, 2, 3)},
+			modified:  model.Matrix{series(model.Metric{"job": "a"}, 1, 2, 3)},
+			tolerance: 0.001,
+		},
+		{
+			name:          "exact match series that disagree produce a diff",
+			original:      model.Matrix{series(model.Metric{"job": "a"}, 1, 2, 3)},
+			modified:      model.Matrix{series(model.Metric{"job": "a"}, 1, 2, 5)},
+			tolerance:     0.001,
+			expectedDiffs: 1,
+		},
+		{
+			name:      "label-less original matches the sum of every fanned-out series",
+			original:  model.Matrix{series(model.Metric{}, 10, 20)},
+			modified:  model.Matrix{series(model.Metric{"cluster": "a"}, 4, 9), series(model.Metric{"cluster": "b"}, 6, 11)},
+			tolerance: 0.001,
+		},
+		{
+			name:          "label-less original disagrees with the sum of the fanned-out series",
+			original:      model.Matrix{series(model.Metric{}, 10)},
+			modified:      model.Matrix{series(model.Metric{"cluster": "a"}, 4), series(model.Metric{"cluster": "b"}, 5)},
+			tolerance:     0.001,
+			expectedDiffs: 1,
+		},
+		{
+			name:      "original with its own labels matches the sum of its fanned-out supersets",
+			original:  model.Matrix{series(model.Metric{"job": "a"}, 10)},
+			modified:  model.Matrix{series(model.Metric{"job": "a", "cluster": "x"}, 4), series(model.Metric{"job": "a", "cluster": "y"}, 6), series(model.Metric{"job": "b", "cluster": "x"}, 100)},
+			tolerance: 0.001,
+		},
+		{
+			name:      "within tolerance produces no diff",
+			original:  model.Matrix{series(model.Metric{"job": "a"}, 1)},
+			modified:  model.Matrix{series(model.Metric{"job": "a"}, 1.0005)},
+			tolerance: 0.001,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			diffs := diffMatrices(tc.original, tc.modified, tc.tolerance)
+			require.Len(t, diffs, tc.expectedDiffs)
+		})
+	}
+}